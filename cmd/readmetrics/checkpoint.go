@@ -0,0 +1,96 @@
+package readmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// defaultCheckpointPath is the conventional checkpoint location, shown as
+// the example in the --checkpoint flag's help. CalculateLatenciesToFile
+// does not fall back to it: with Options.CheckpointPath empty, no
+// checkpoint is read or written.
+const defaultCheckpointPath = "tmp/log_metrics.ckpt"
+
+// fileIdentity identifies a file across process restarts so a checkpoint
+// can detect that the log was rotated out from under it. It is backed by
+// the device/inode pair rather than path or size, which survive rename-
+// based log rotation.
+type fileIdentity struct {
+	Dev uint64 `json:"dev"`
+	Ino uint64 `json:"ino"`
+}
+
+// identifyFile extracts the fileIdentity of an open file's FileInfo.
+func identifyFile(info os.FileInfo) fileIdentity {
+	if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileIdentity{Dev: uint64(sys.Dev), Ino: sys.Ino}
+	}
+	return fileIdentity{}
+}
+
+// checkpointDMessage is the JSON-serializable form of a LogMetricsEntry
+// kept across runs while waiting for its 35=8 ack.
+type checkpointDMessage struct {
+	Timestamp time.Time         `json:"timestamp"`
+	MsgType   string            `json:"msg_type"`
+	ClOrdID   string            `json:"cl_ord_id"`
+	Fields    map[string]string `json:"fields"`
+}
+
+// Checkpoint is the durable resume state written to Options.CheckpointPath
+// so a re-run of CalculateLatenciesToFile against a growing log can pick
+// up where it left off instead of rescanning from byte 0.
+type Checkpoint struct {
+	File                  fileIdentity                  `json:"file"`
+	FileSize              int64                         `json:"file_size"`
+	Offset                int64                         `json:"offset"`
+	PendingDMessages      map[string]checkpointDMessage `json:"pending_d_messages"`
+	ThroughputPartialMins map[string]int                `json:"throughput_partial_minute"`
+}
+
+// loadCheckpoint reads a Checkpoint from path, returning (nil, nil) if no
+// checkpoint file exists yet.
+func loadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+
+	var ck Checkpoint
+	if err := json.Unmarshal(data, &ck); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint: %v", err)
+	}
+	return &ck, nil
+}
+
+// saveCheckpoint writes ck to path as JSON, creating parent-less tmp/
+// paths as used elsewhere in this package.
+func saveCheckpoint(path string, ck Checkpoint) error {
+	data, err := json.MarshalIndent(ck, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling checkpoint: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	return nil
+}
+
+// resumable reports whether ck was captured against the same file (by
+// device/inode) and the file has not been truncated or rotated out from
+// under it since, i.e. it is safe to seek to ck.Offset and continue.
+func resumable(ck *Checkpoint, info os.FileInfo) bool {
+	if ck == nil {
+		return false
+	}
+	if identifyFile(info) != ck.File {
+		return false
+	}
+	return info.Size() >= ck.Offset
+}
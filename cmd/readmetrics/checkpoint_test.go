@@ -0,0 +1,100 @@
+package readmetrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadCheckpointMissingFileReturnsNilNil(t *testing.T) {
+	ck, err := loadCheckpoint(filepath.Join(t.TempDir(), "does-not-exist.ckpt"))
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error = %v, want nil", err)
+	}
+	if ck != nil {
+		t.Fatalf("loadCheckpoint() = %+v, want nil", ck)
+	}
+}
+
+func TestSaveAndLoadCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ckpt")
+	want := Checkpoint{
+		File:     fileIdentity{Dev: 1, Ino: 2},
+		FileSize: 100,
+		Offset:   42,
+		PendingDMessages: map[string]checkpointDMessage{
+			"ORD1": {Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), MsgType: "D", ClOrdID: "ORD1", Fields: map[string]string{"55": "AAPL"}},
+		},
+		ThroughputPartialMins: map[string]int{"2024-01-01 00:00": 3},
+	}
+
+	if err := saveCheckpoint(path, want); err != nil {
+		t.Fatalf("saveCheckpoint() error: %v", err)
+	}
+
+	got, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint() error: %v", err)
+	}
+	if got == nil {
+		t.Fatalf("loadCheckpoint() = nil, want the saved checkpoint")
+	}
+	if got.Offset != want.Offset || got.FileSize != want.FileSize || got.File != want.File {
+		t.Errorf("loadCheckpoint() = %+v, want %+v", got, want)
+	}
+	if got.PendingDMessages["ORD1"].ClOrdID != "ORD1" {
+		t.Errorf("PendingDMessages[ORD1] = %+v, want ClOrdID ORD1", got.PendingDMessages["ORD1"])
+	}
+	if got.ThroughputPartialMins["2024-01-01 00:00"] != 3 {
+		t.Errorf("ThroughputPartialMins[2024-01-01 00:00] = %d, want 3", got.ThroughputPartialMins["2024-01-01 00:00"])
+	}
+}
+
+func openTempFile(t *testing.T) (*os.File, os.FileInfo) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "log"))
+	if err != nil {
+		t.Fatalf("os.Create() error: %v", err)
+	}
+	t.Cleanup(func() { f.Close() })
+	if _, err := f.WriteString("hello"); err != nil {
+		t.Fatalf("f.WriteString() error: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatalf("f.Stat() error: %v", err)
+	}
+	return f, info
+}
+
+func TestResumableNilCheckpoint(t *testing.T) {
+	_, info := openTempFile(t)
+	if resumable(nil, info) {
+		t.Errorf("resumable(nil, info) = true, want false")
+	}
+}
+
+func TestResumableSameFile(t *testing.T) {
+	_, info := openTempFile(t)
+	ck := &Checkpoint{File: identifyFile(info), Offset: 3}
+	if !resumable(ck, info) {
+		t.Errorf("resumable() = false, want true for a checkpoint against the same, still-grown-or-equal file")
+	}
+}
+
+func TestResumableDifferentFileIdentity(t *testing.T) {
+	_, info := openTempFile(t)
+	ck := &Checkpoint{File: fileIdentity{Dev: 999, Ino: 999}, Offset: 0}
+	if resumable(ck, info) {
+		t.Errorf("resumable() = true, want false when the file's dev/ino no longer matches (rotation)")
+	}
+}
+
+func TestResumableTruncatedFile(t *testing.T) {
+	_, info := openTempFile(t)
+	ck := &Checkpoint{File: identifyFile(info), Offset: int64(len("hello")) + 1}
+	if resumable(ck, info) {
+		t.Errorf("resumable() = true, want false when the checkpoint's offset is past the file's current size (truncation)")
+	}
+}
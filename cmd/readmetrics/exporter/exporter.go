@@ -0,0 +1,91 @@
+// Package exporter serves the readmetrics FIX processing pipeline as a
+// Prometheus-compatible /metrics endpoint.
+package exporter
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// latencyBucketsMs mirrors the default histogram bounds used elsewhere in
+// readmetrics so the Prometheus histogram and log_metrics.txt histogram
+// agree.
+var latencyBucketsMs = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// labelNames is the set of labels attached to every per-order metric.
+var labelNames = []string{"sender_comp_id", "target_comp_id", "symbol"}
+
+// Exporter holds the Prometheus instruments for the FIX metrics pipeline
+// and exposes them over HTTP.
+type Exporter struct {
+	registry        *prometheus.Registry
+	ordersSent      *prometheus.CounterVec
+	ordersAcked     *prometheus.CounterVec
+	unmatchedOrders *prometheus.GaugeVec
+	latencyMs       *prometheus.HistogramVec
+	messagesByType  *prometheus.CounterVec
+}
+
+// New creates an Exporter with a fresh, isolated Prometheus registry.
+func New() *Exporter {
+	e := &Exporter{
+		registry: prometheus.NewRegistry(),
+		ordersSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "readmetrics_orders_sent_total",
+			Help: "Total number of 35=D (NewOrderSingle) messages observed.",
+		}, labelNames),
+		ordersAcked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "readmetrics_orders_acked_total",
+			Help: "Total number of 35=D messages matched to a 35=8 execution report.",
+		}, labelNames),
+		unmatchedOrders: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "readmetrics_unmatched_orders",
+			Help: "Number of 35=D messages sent but not yet acked.",
+		}, labelNames),
+		latencyMs: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "readmetrics_order_latency_milliseconds",
+			Help:    "Round-trip latency between a 35=D message and its matching 35=8.",
+			Buckets: latencyBucketsMs,
+		}, labelNames),
+		messagesByType: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "readmetrics_messages_total",
+			Help: "Total number of FIX messages observed, by MsgType.",
+		}, []string{"msg_type"}),
+	}
+
+	e.registry.MustRegister(e.ordersSent, e.ordersAcked, e.unmatchedOrders, e.latencyMs, e.messagesByType)
+	return e
+}
+
+// RecordOrderSent records a 35=D message for the given SenderCompID,
+// TargetCompID, and Symbol.
+func (e *Exporter) RecordOrderSent(senderCompID, targetCompID, symbol string) {
+	e.ordersSent.WithLabelValues(senderCompID, targetCompID, symbol).Inc()
+	e.unmatchedOrders.WithLabelValues(senderCompID, targetCompID, symbol).Inc()
+	e.messagesByType.WithLabelValues("D").Inc()
+}
+
+// RecordOrderAcked records a 35=8 message that matched a previously sent
+// 35=D, with the round-trip latency in milliseconds.
+func (e *Exporter) RecordOrderAcked(senderCompID, targetCompID, symbol string, latencyMs float64) {
+	e.ordersAcked.WithLabelValues(senderCompID, targetCompID, symbol).Inc()
+	e.unmatchedOrders.WithLabelValues(senderCompID, targetCompID, symbol).Dec()
+	e.latencyMs.WithLabelValues(senderCompID, targetCompID, symbol).Observe(latencyMs)
+	e.messagesByType.WithLabelValues("8").Inc()
+}
+
+// RecordMessage records a FIX message not already counted by
+// RecordOrderSent/RecordOrderAcked (e.g. heartbeats, rejects, or a 35=8
+// that matched no 35=D), so readmetrics_messages_total counts every
+// message seen in the log, not just 35=D and matched 35=8.
+func (e *Exporter) RecordMessage(msgType string) {
+	e.messagesByType.WithLabelValues(msgType).Inc()
+}
+
+// Handler returns the http.Handler that serves this Exporter's metrics in
+// the OpenMetrics/Prometheus text exposition format.
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{EnableOpenMetrics: true})
+}
@@ -0,0 +1,317 @@
+package readmetrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options controls how Execute and CalculateLatenciesToFile process and
+// render the FIX log.
+type Options struct {
+	// Format selects the output formatter: "json" (default), "csv", or
+	// "logfmt".
+	Format string
+	// Fields is the set of FIX tags to include as extra columns/keys in
+	// the per-message output, e.g. []int{11, 35, 54, 55}.
+	Fields []int
+	// ExactSamples keeps every latency sample in memory for exact
+	// percentiles instead of the bounded reservoir sketch. Suitable for
+	// small logs; for multi-GB logs leave this false.
+	ExactSamples bool
+	// HistogramBoundsMs overrides the default latency histogram bucket
+	// upper bounds, in milliseconds.
+	HistogramBoundsMs []int64
+	// CheckpointPath, if set, enables checkpoint/resume: the scan
+	// position and in-flight 35=D messages are persisted here so a
+	// subsequent call resumes instead of rescanning the whole log.
+	//
+	// IMPORTANT: only the scan offset, pending 35=D messages, and the
+	// latest throughput minute are persisted — latency samples are not.
+	// After a resume, MetricsSummary.Latency reflects only the messages
+	// processed in that run, not the full history of the log. Do not
+	// treat a resumed run's stats as a complete picture; aggregate them
+	// externally across runs if that's needed.
+	CheckpointPath string
+	// Reset discards any existing checkpoint at CheckpointPath and
+	// rescans the log from the beginning.
+	Reset bool
+}
+
+// MetricsRecord is a single row of the structured metrics stream: one
+// 35=D or 35=8 message with its extracted FIX tags and, once the
+// matching ack has been seen, the round-trip latency.
+type MetricsRecord struct {
+	Timestamp  string
+	MsgType    string
+	ClOrdID    string
+	LatencyMs  int64
+	HasLatency bool
+	Fields     map[string]string
+}
+
+// MetricsSummary is the trailing summary record emitted after the
+// per-message stream. When Options.CheckpointPath is set and the run
+// resumes from a checkpoint, Latency and MessageCount cover only the
+// messages scanned in this run, not the log's full history: checkpoints
+// persist the scan offset and pending 35=D messages, not latency
+// samples, so stats do not accumulate across resumed runs.
+type MetricsSummary struct {
+	MessageCount int
+	Latency      LatencyStats
+	Throughput   map[string]int
+}
+
+// Formatter writes a stream of MetricsRecord followed by a single
+// MetricsSummary.
+type Formatter interface {
+	WriteRecord(w io.Writer, rec MetricsRecord) error
+	WriteSummary(w io.Writer, summary MetricsSummary) error
+}
+
+// NewFormatter returns the Formatter registered for name, defaulting to
+// the JSON formatter for an empty or unrecognized name.
+func NewFormatter(name string, fields []int) Formatter {
+	switch name {
+	case "csv":
+		return &csvFormatter{fields: fields}
+	case "logfmt":
+		return &logfmtFormatter{fields: fields}
+	default:
+		return &jsonFormatter{}
+	}
+}
+
+// outputFileName returns the tmp/ path CalculateLatenciesToFile should
+// write its formatted output to for the given format.
+func outputFileName(format string) string {
+	switch format {
+	case "csv":
+		return "tmp/log_metrics.csv"
+	case "logfmt":
+		return "tmp/log_metrics.log"
+	default:
+		return "tmp/log_metrics.txt"
+	}
+}
+
+// summaryFileName returns the path CalculateLatenciesToFile should write
+// its trailing MetricsSummary to for the given format. CSV's summary has
+// a different column count per row than a per-message record, so it gets
+// its own file rather than being appended after the ragged rows it would
+// otherwise produce in log_metrics.csv; json and logfmt summaries are
+// self-describing and stay in the main output file.
+func summaryFileName(format string) string {
+	if format == "csv" {
+		return "tmp/log_metrics_summary.csv"
+	}
+	return outputFileName(format)
+}
+
+// parseFields parses a comma-separated list of FIX tags, e.g.
+// "11,35,54,55", as passed via the --fields flag.
+func parseFields(s string) []int {
+	if s == "" {
+		return nil
+	}
+	var tags []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		tag, err := strconv.Atoi(part)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// jsonFormatter writes one JSON object per record (JSON Lines), followed
+// by a final JSON object for the summary.
+type jsonFormatter struct{}
+
+func (f *jsonFormatter) WriteRecord(w io.Writer, rec MetricsRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("error marshaling record: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func (f *jsonFormatter) WriteSummary(w io.Writer, summary MetricsSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("error marshaling summary: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// csvFormatter emits timestamp, MsgType, ClOrdID, latency_ms, plus one
+// column per selected FIX tag. The header row is written before the
+// first record.
+type csvFormatter struct {
+	fields     []int
+	wroteOneOf bool
+	w          *csv.Writer
+}
+
+func (f *csvFormatter) writer(w io.Writer) *csv.Writer {
+	if f.w == nil {
+		f.w = csv.NewWriter(w)
+	}
+	return f.w
+}
+
+func (f *csvFormatter) header() []string {
+	header := []string{"timestamp", "MsgType", "ClOrdID", "latency_ms"}
+	for _, tag := range f.fields {
+		header = append(header, strconv.Itoa(tag))
+	}
+	return header
+}
+
+func (f *csvFormatter) WriteRecord(w io.Writer, rec MetricsRecord) error {
+	cw := f.writer(w)
+	if !f.wroteOneOf {
+		if err := cw.Write(f.header()); err != nil {
+			return fmt.Errorf("error writing csv header: %v", err)
+		}
+		f.wroteOneOf = true
+	}
+
+	latency := ""
+	if rec.HasLatency {
+		latency = strconv.FormatInt(rec.LatencyMs, 10)
+	}
+
+	row := []string{rec.Timestamp, rec.MsgType, rec.ClOrdID, latency}
+	for _, tag := range f.fields {
+		row = append(row, rec.Fields[strconv.Itoa(tag)])
+	}
+
+	if err := cw.Write(row); err != nil {
+		return fmt.Errorf("error writing csv row: %v", err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSummary writes the summary to w as its own small CSV, independent
+// of the WriteRecord stream: the summary's rows (message_count,
+// latency_*, latency_bucket, throughput) have a different column count
+// per row than a per-message record, so mixing the two into one CSV
+// produces a file no spreadsheet/BI tool can load without post-processing.
+// Callers should give WriteSummary a separate file/writer from the one
+// used for WriteRecord (see outputFileName vs summaryFileName).
+func (f *csvFormatter) WriteSummary(w io.Writer, summary MetricsSummary) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"message_count", strconv.Itoa(summary.MessageCount)}); err != nil {
+		return err
+	}
+
+	stats := summary.Latency
+	statRows := [][]string{
+		{"latency_min_ms", strconv.FormatInt(stats.MinMs, 10)},
+		{"latency_max_ms", strconv.FormatInt(stats.MaxMs, 10)},
+		{"latency_mean_ms", strconv.FormatFloat(stats.MeanMs, 'f', 2, 64)},
+		{"latency_stddev_ms", strconv.FormatFloat(stats.StdDevMs, 'f', 2, 64)},
+		{"latency_p50_ms", strconv.FormatFloat(stats.P50Ms, 'f', 2, 64)},
+		{"latency_p90_ms", strconv.FormatFloat(stats.P90Ms, 'f', 2, 64)},
+		{"latency_p95_ms", strconv.FormatFloat(stats.P95Ms, 'f', 2, 64)},
+		{"latency_p99_ms", strconv.FormatFloat(stats.P99Ms, 'f', 2, 64)},
+		{"latency_p999_ms", strconv.FormatFloat(stats.P999Ms, 'f', 2, 64)},
+	}
+	for _, row := range statRows {
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	for _, bucket := range stats.Histogram {
+		label := strconv.FormatInt(bucket.UpperBoundMs, 10)
+		if bucket.UpperBoundMs < 0 {
+			label = "+Inf"
+		}
+		if err := cw.Write([]string{"latency_bucket", label, strconv.FormatInt(bucket.Count, 10)}); err != nil {
+			return err
+		}
+	}
+
+	minutes := make([]string, 0, len(summary.Throughput))
+	for minute := range summary.Throughput {
+		minutes = append(minutes, minute)
+	}
+	sort.Strings(minutes)
+	for _, minute := range minutes {
+		if err := cw.Write([]string{"throughput", minute, strconv.Itoa(summary.Throughput[minute])}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// logfmtFormatter emits one key=value line per record and one for the
+// summary, in the style of standard Go logging middleware.
+type logfmtFormatter struct {
+	fields []int
+}
+
+func (f *logfmtFormatter) WriteRecord(w io.Writer, rec MetricsRecord) error {
+	pairs := []string{
+		fmt.Sprintf("ts=%q", rec.Timestamp),
+		fmt.Sprintf("msgtype=%s", rec.MsgType),
+		fmt.Sprintf("clordid=%s", rec.ClOrdID),
+	}
+	if rec.HasLatency {
+		pairs = append(pairs, fmt.Sprintf("latency_ms=%d", rec.LatencyMs))
+	}
+	for _, tag := range f.fields {
+		key := strconv.Itoa(tag)
+		if value, ok := rec.Fields[key]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	_, err := fmt.Fprintln(w, strings.Join(pairs, " "))
+	return err
+}
+
+func (f *logfmtFormatter) WriteSummary(w io.Writer, summary MetricsSummary) error {
+	stats := summary.Latency
+	_, err := fmt.Fprintf(w, "message_count=%d latency_min_ms=%d latency_max_ms=%d latency_mean_ms=%.2f latency_stddev_ms=%.2f latency_p50_ms=%.2f latency_p90_ms=%.2f latency_p95_ms=%.2f latency_p99_ms=%.2f latency_p999_ms=%.2f\n",
+		summary.MessageCount, stats.MinMs, stats.MaxMs, stats.MeanMs, stats.StdDevMs, stats.P50Ms, stats.P90Ms, stats.P95Ms, stats.P99Ms, stats.P999Ms)
+	if err != nil {
+		return err
+	}
+
+	for _, bucket := range stats.Histogram {
+		label := strconv.FormatInt(bucket.UpperBoundMs, 10)
+		if bucket.UpperBoundMs < 0 {
+			label = "+Inf"
+		}
+		if _, err := fmt.Fprintf(w, "latency_bucket le=%s count=%d\n", label, bucket.Count); err != nil {
+			return err
+		}
+	}
+
+	minutes := make([]string, 0, len(summary.Throughput))
+	for minute := range summary.Throughput {
+		minutes = append(minutes, minute)
+	}
+	sort.Strings(minutes)
+	for _, minute := range minutes {
+		if _, err := fmt.Fprintf(w, "throughput minute=%q orders_per_min=%d\n", minute, summary.Throughput[minute]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package readmetrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []int
+	}{
+		{name: "empty string yields nil", in: "", want: nil},
+		{name: "single tag", in: "35", want: []int{35}},
+		{name: "multiple tags", in: "11,35,54,55", want: []int{11, 35, 54, 55}},
+		{name: "whitespace around tags is trimmed", in: " 11 , 35 ", want: []int{11, 35}},
+		{name: "non-numeric entries are skipped", in: "11,abc,35", want: []int{11, 35}},
+		{name: "empty entries between commas are skipped", in: "11,,35", want: []int{11, 35}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseFields(tt.in)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseFields(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseFields(%q)[%d] = %d, want %d", tt.in, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func sampleSummary() MetricsSummary {
+	return MetricsSummary{
+		MessageCount: 2,
+		Latency: LatencyStats{
+			Count: 2, MinMs: 10, MaxMs: 20, MeanMs: 15, StdDevMs: 5,
+			P50Ms: 15, P90Ms: 19, P95Ms: 19.5, P99Ms: 19.9, P999Ms: 19.99,
+			Histogram: []HistogramBucket{
+				{UpperBoundMs: 10, Count: 1},
+				{UpperBoundMs: 20, Count: 1},
+				{UpperBoundMs: -1, Count: 0},
+			},
+		},
+		Throughput: map[string]int{"2024-01-01 00:01": 3, "2024-01-01 00:00": 2},
+	}
+}
+
+func TestCSVFormatterWriteRecordWritesHeaderOnce(t *testing.T) {
+	f := &csvFormatter{fields: []int{55}}
+	var buf bytes.Buffer
+
+	rec := MetricsRecord{Timestamp: "t1", MsgType: "D", ClOrdID: "ORD1", Fields: map[string]string{"55": "AAPL"}}
+	if err := f.WriteRecord(&buf, rec); err != nil {
+		t.Fatalf("WriteRecord() error: %v", err)
+	}
+	if err := f.WriteRecord(&buf, rec); err != nil {
+		t.Fatalf("WriteRecord() error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3 (1 header + 2 rows); output:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "timestamp,MsgType,ClOrdID,latency_ms,55" {
+		t.Errorf("header = %q, want the csv header with the selected field appended", lines[0])
+	}
+	if lines[1] != "t1,D,ORD1,,AAPL" {
+		t.Errorf("row = %q, want %q", lines[1], "t1,D,ORD1,,AAPL")
+	}
+}
+
+func TestCSVFormatterWriteSummaryHasUniformRowShapePerSection(t *testing.T) {
+	f := &csvFormatter{}
+	var buf bytes.Buffer
+
+	if err := f.WriteSummary(&buf, sampleSummary()); err != nil {
+		t.Fatalf("WriteSummary() error: %v", err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"message_count,2",
+		"latency_min_ms,10",
+		"latency_bucket,10,1",
+		"latency_bucket,+Inf,0",
+		"throughput,2024-01-01 00:00,2",
+		"throughput,2024-01-01 00:01,3",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary output missing row %q; got:\n%s", want, got)
+		}
+	}
+
+	// Throughput rows must come out sorted by minute regardless of map
+	// iteration order.
+	if strings.Index(got, "2024-01-01 00:00") > strings.Index(got, "2024-01-01 00:01") {
+		t.Errorf("throughput rows are not sorted by minute; got:\n%s", got)
+	}
+}
+
+func TestCSVFormatterWriteSummaryIsIndependentOfWriteRecord(t *testing.T) {
+	// Regression: WriteSummary must not reuse the csv.Writer cached by
+	// WriteRecord, since it's meant to be written to a separate file
+	// (see summaryFileName) rather than appended after record rows.
+	f := &csvFormatter{}
+	var records bytes.Buffer
+	if err := f.WriteRecord(&records, MetricsRecord{Timestamp: "t1", MsgType: "D", ClOrdID: "ORD1"}); err != nil {
+		t.Fatalf("WriteRecord() error: %v", err)
+	}
+
+	var summary bytes.Buffer
+	if err := f.WriteSummary(&summary, sampleSummary()); err != nil {
+		t.Fatalf("WriteSummary() error: %v", err)
+	}
+
+	if summary.Len() == 0 {
+		t.Fatalf("WriteSummary() wrote nothing to its own writer")
+	}
+	if strings.Contains(summary.String(), "ORD1") {
+		t.Errorf("summary writer unexpectedly contains record data: %s", summary.String())
+	}
+}
+
+func TestLogfmtFormatterWriteRecord(t *testing.T) {
+	f := &logfmtFormatter{fields: []int{55}}
+	var buf bytes.Buffer
+
+	rec := MetricsRecord{
+		Timestamp: "t1", MsgType: "8", ClOrdID: "ORD1",
+		LatencyMs: 42, HasLatency: true,
+		Fields: map[string]string{"55": "AAPL"},
+	}
+	if err := f.WriteRecord(&buf, rec); err != nil {
+		t.Fatalf("WriteRecord() error: %v", err)
+	}
+
+	got := strings.TrimRight(buf.String(), "\n")
+	want := `ts="t1" msgtype=8 clordid=ORD1 latency_ms=42 55=AAPL`
+	if got != want {
+		t.Errorf("WriteRecord() = %q, want %q", got, want)
+	}
+}
+
+func TestLogfmtFormatterWriteRecordOmitsLatencyWhenUnset(t *testing.T) {
+	f := &logfmtFormatter{}
+	var buf bytes.Buffer
+
+	rec := MetricsRecord{Timestamp: "t1", MsgType: "D", ClOrdID: "ORD1"}
+	if err := f.WriteRecord(&buf, rec); err != nil {
+		t.Fatalf("WriteRecord() error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "latency_ms") {
+		t.Errorf("WriteRecord() = %q, want no latency_ms field when HasLatency is false", buf.String())
+	}
+}
+
+func TestOutputFileNameAndSummaryFileName(t *testing.T) {
+	tests := []struct {
+		format          string
+		wantOutput      string
+		wantSummarySame bool
+	}{
+		{format: "csv", wantOutput: "tmp/log_metrics.csv", wantSummarySame: false},
+		{format: "logfmt", wantOutput: "tmp/log_metrics.log", wantSummarySame: true},
+		{format: "json", wantOutput: "tmp/log_metrics.txt", wantSummarySame: true},
+		{format: "", wantOutput: "tmp/log_metrics.txt", wantSummarySame: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := outputFileName(tt.format); got != tt.wantOutput {
+				t.Errorf("outputFileName(%q) = %q, want %q", tt.format, got, tt.wantOutput)
+			}
+			same := summaryFileName(tt.format) == outputFileName(tt.format)
+			if same != tt.wantSummarySame {
+				t.Errorf("summaryFileName(%q) == outputFileName(...) = %v, want %v", tt.format, same, tt.wantSummarySame)
+			}
+		})
+	}
+}
@@ -2,12 +2,21 @@ package readmetrics
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
+
+	"github.com/joao-ferreira-encora/examples/cmd/readmetrics/exporter"
+	"github.com/joao-ferreira-encora/examples/fixparse"
 )
 
 // Constants for file paths
@@ -26,10 +35,129 @@ type LogMetricsEntry struct {
 	timestamp time.Time
 	msgType   string
 	clOrdID   string
+	fields    map[string]string
 }
 
-// Execute reads the log file, extracts relevant information, and saves it as JSON
+// Execute reads the log file, extracts relevant information, and saves it as JSON.
+// It parses --format and --fields from the command line; for programmatic
+// use see ExecuteWithOptions.
 func Execute() error {
+	fs := flag.NewFlagSet("readmetrics", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format for the metrics stream: json, csv, or logfmt")
+	fields := fs.String("fields", "", "comma-separated FIX tags to include in the per-message output, e.g. 11,35,54,55")
+	follow := fs.Bool("follow", false, "follow the log file like tail -f instead of scanning it once")
+	checkpointPath := fs.String("checkpoint", "", "path to a checkpoint file (e.g. "+defaultCheckpointPath+") enabling resume on a growing log; leave empty to always rescan from byte 0")
+	reset := fs.Bool("reset", false, "discard the existing checkpoint and rescan the log from the beginning")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+
+	opts := Options{
+		Format:         *format,
+		Fields:         parseFields(*fields),
+		CheckpointPath: *checkpointPath,
+		Reset:          *reset,
+	}
+
+	if *follow {
+		return followLog(opts)
+	}
+
+	return ExecuteWithOptions(opts)
+}
+
+// followLog runs Watch against LogFilePath until interrupted, printing
+// each event as it arrives. A normal SIGINT/SIGTERM shutdown reports as a
+// nil error, the same way StartExporter treats http.ErrServerClosed as
+// clean.
+func followLog(opts Options) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	err := Watch(ctx, LogFilePath, printEvent)
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil
+	}
+	return err
+}
+
+// StartExporter serves a Prometheus-compatible /metrics endpoint on addr,
+// driven by its own Watch loop over LogFilePath. It blocks until the HTTP
+// server stops or ctx is canceled via SIGINT/SIGTERM. To run the exporter
+// alongside --follow off a single shared Watch loop instead of each
+// opening its own, use StartExporterWithFollow.
+func StartExporter(addr string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return startExporter(ctx, addr, nil)
+}
+
+// StartExporterWithFollow serves the /metrics endpoint exactly like
+// StartExporter, but also prints every event like --follow, both off the
+// same Watch call over LogFilePath rather than two independent ones.
+func StartExporterWithFollow(addr string) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return startExporter(ctx, addr, printEvent)
+}
+
+// printEvent prints a single event in the same format followLog uses.
+func printEvent(event Event) {
+	switch e := event.(type) {
+	case OrderSent:
+		fmt.Printf("order sent: ClOrdID=%s at %s\n", e.ClOrdID, e.Timestamp.Format(time.RFC3339Nano))
+	case OrderAcked:
+		fmt.Printf("order acked: ClOrdID=%s latency=%s\n", e.ClOrdID, e.Latency)
+	case ThroughputTick:
+		fmt.Printf("throughput: minute=%s orders=%d\n", e.Minute.Format("2006-01-02 15:04"), e.Count)
+	}
+}
+
+// startExporter runs the exporter's HTTP server and its Watch loop. If
+// extra is non-nil, every event is also delivered to it via fanOut, so a
+// caller can drive --follow's printing off the same loop.
+func startExporter(ctx context.Context, addr string, extra Handler) error {
+	exp := exporter.New()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp.Handler())
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	recordEvent := func(event Event) {
+		switch e := event.(type) {
+		case OrderSent:
+			exp.RecordOrderSent(e.Fields["49"], e.Fields["56"], e.Fields["55"])
+		case OrderAcked:
+			exp.RecordOrderAcked(e.Fields["49"], e.Fields["56"], e.Fields["55"], float64(e.Latency.Milliseconds()))
+		case MessageSeen:
+			exp.RecordMessage(e.MsgType)
+		}
+	}
+	handler := Handler(recordEvent)
+	if extra != nil {
+		handler = fanOut(recordEvent, extra)
+	}
+
+	go func() {
+		if err := Watch(ctx, LogFilePath, handler); err != nil && err != context.Canceled {
+			fmt.Println("error watching log for exporter:", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("error serving metrics: %v", err)
+	}
+	return nil
+}
+
+// ExecuteWithOptions runs Execute with an explicit Options value, bypassing
+// command-line flag parsing.
+func ExecuteWithOptions(opts Options) error {
 	dir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("error getting working directory: %v", err)
@@ -46,30 +174,21 @@ func Execute() error {
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		if !strings.Contains(line, "35=D") && !strings.Contains(line, "35=8") {
+			continue
+		}
 
-		if strings.Contains(line, "35=D") || strings.Contains(line, "35=8") {
-			entry := LogEntry{
-				Fields: make(map[string]string),
-			}
-
-			parts := strings.Split(line, " ")
-			if len(parts) > 2 {
-				entry.MessageType = strings.Split(parts[2], "\u0001")[0]
-				entry.Timestamp = parts[1]
-
-				// Extract fields
-				for _, part := range parts {
-					if strings.Contains(part, "=") {
-						keyValue := strings.SplitN(part, "=", 2)
-						if len(keyValue) == 2 {
-							entry.Fields[keyValue[0]] = keyValue[1]
-						}
-					}
-				}
-			}
-
-			entries = append(entries, entry)
+		msg, err := parseFIXMessage(line)
+		if err != nil {
+			fmt.Println("Error parsing line:", err)
+			continue
 		}
+
+		entries = append(entries, LogEntry{
+			MessageType: msg.msgType,
+			Timestamp:   msg.timestamp.Format("15:04:05.000000"),
+			Fields:      msg.fields,
+		})
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -80,7 +199,7 @@ func Execute() error {
 		return fmt.Errorf("error saving to JSON: %v", err)
 	}
 
-	if err := CalculateLatenciesToFile(LogFilePath); err != nil {
+	if err := CalculateLatenciesToFile(LogFilePath, opts); err != nil {
 		return fmt.Errorf("error calculating latencies: %v", err)
 	}
 
@@ -113,39 +232,149 @@ func saveToJSON(entries []LogEntry) error {
 	return nil
 }
 
-// parseFIXMessage parses a FIX message from a log line.
+// parseFIXMessage parses a FIX message from a log line. Each line is a
+// 26-character timestamp followed by the SOH-delimited FIX fields,
+// which are decoded with fixparse rather than by splitting on spaces.
 func parseFIXMessage(line string) (LogMetricsEntry, error) {
-	fields := strings.Split(line, "")
-	msg := LogMetricsEntry{}
-	timestampStr := line[:26]
-	timestamp, err := time.Parse("2006/01/02 15:04:05.000000", timestampStr)
+	if len(line) < 26 {
+		return LogMetricsEntry{}, fmt.Errorf("line too short for timestamp: %q", line)
+	}
+
+	timestamp, err := time.Parse("2006/01/02 15:04:05.000000", line[:26])
 	if err != nil {
-		return msg, err
+		return LogMetricsEntry{}, err
 	}
-	msg.timestamp = timestamp
 
-	for _, field := range fields {
-		if strings.HasPrefix(field, "35=") {
-			msg.msgType = strings.TrimPrefix(field, "35=")
-		} else if strings.HasPrefix(field, "11=") {
-			msg.clOrdID = strings.TrimPrefix(field, "11=")
-		}
+	rest := strings.TrimPrefix(line[26:], " ")
+	fixMsg, err := fixparse.DecodeMessageString(rest)
+	if err != nil {
+		return LogMetricsEntry{}, fmt.Errorf("error decoding FIX message: %v", err)
 	}
-	return msg, nil
+
+	return LogMetricsEntry{
+		timestamp: timestamp,
+		msgType:   fixMsg.MsgType(),
+		clOrdID:   fixMsg.ClOrdID(),
+		fields:    fixMsg.Fields(),
+	}, nil
 }
 
-// CalculateLatenciesToFile reads a log file, calculates latencies for 35=D messages,
-// and writes the latencies and throughput to a file in the /tmp directory.
-func CalculateLatenciesToFile(logFilePath string) error {
+// CalculateLatenciesToFile reads a log file, calculates latencies for 35=D
+// messages, and writes the per-message records plus a summary to the tmp/
+// directory, rendered via opts.Format (see NewFormatter); csv puts its
+// summary in a file of its own (see summaryFileName). If
+// opts.CheckpointPath is set, it resumes from the last saved offset and
+// in-flight 35=D messages instead of rescanning the whole file.
+func CalculateLatenciesToFile(logFilePath string, opts Options) error {
 	file, err := os.Open(logFilePath)
 	if err != nil {
 		return fmt.Errorf("error opening file: %v", err)
 	}
 	defer file.Close()
 
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error stat-ing file: %v", err)
+	}
+
+	var ck *Checkpoint
+	if opts.CheckpointPath != "" {
+		if opts.Reset {
+			if err := os.Remove(opts.CheckpointPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("error resetting checkpoint: %v", err)
+			}
+		} else if ck, err = loadCheckpoint(opts.CheckpointPath); err != nil {
+			return err
+		}
+	}
+
 	dMessages := make(map[string]LogMetricsEntry)
-	latencies := []int64{} // Store latencies in an array for average calculation
 	throughputCounts := make(map[time.Time]int)
+	resuming := resumable(ck, info)
+
+	if resuming {
+		if _, err := file.Seek(ck.Offset, io.SeekStart); err != nil {
+			return fmt.Errorf("error seeking to checkpoint offset: %v", err)
+		}
+		for clOrdID, pending := range ck.PendingDMessages {
+			dMessages[clOrdID] = LogMetricsEntry{
+				timestamp: pending.Timestamp,
+				msgType:   pending.MsgType,
+				clOrdID:   pending.ClOrdID,
+				fields:    pending.Fields,
+			}
+		}
+		for minuteStr, count := range ck.ThroughputPartialMins {
+			minute, err := time.Parse("2006-01-02 15:04", minuteStr)
+			if err != nil {
+				continue
+			}
+			throughputCounts[minute] = count
+		}
+	} else {
+		ck = nil
+	}
+
+	if resuming && ck.Offset == info.Size() {
+		// Nothing new has been appended since the last checkpoint; skip
+		// rescanning and re-emitting a summary so repeated runs against a
+		// static log don't overwrite/append an empty block.
+		return nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("error getting working directory: %v", err)
+	}
+	outputPath := filepath.Join(dir, outputFileName(opts.Format))
+	summaryPath := filepath.Join(dir, summaryFileName(opts.Format))
+
+	var outputFile *os.File
+	if resuming {
+		outputFile, err = os.OpenFile(outputPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	} else {
+		outputFile, err = os.Create(outputPath)
+	}
+	if err != nil {
+		return fmt.Errorf("error creating log file: %v", err)
+	}
+	defer outputFile.Close()
+
+	writer := bufio.NewWriter(outputFile)
+
+	// For formats whose summary has a different shape than its per-record
+	// rows (currently csv), summaryPath names a separate file so the two
+	// row shapes don't end up interleaved in one file; other formats
+	// share the same file and writer as the per-record stream.
+	summaryWriter := writer
+	if summaryPath != outputPath {
+		var summaryFile *os.File
+		if resuming {
+			summaryFile, err = os.OpenFile(summaryPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		} else {
+			summaryFile, err = os.Create(summaryPath)
+		}
+		if err != nil {
+			return fmt.Errorf("error creating summary file: %v", err)
+		}
+		defer summaryFile.Close()
+		summaryWriter = bufio.NewWriter(summaryFile)
+	}
+
+	formatter := NewFormatter(opts.Format, opts.Fields)
+	if resuming {
+		if cf, ok := formatter.(*csvFormatter); ok {
+			cf.wroteOneOf = true // header was already written by the run being resumed
+		}
+	}
+
+	var sketch Sketch
+	if opts.ExactSamples {
+		sketch = NewExactSketch()
+	} else {
+		sketch = NewReservoirSketch(reservoirSize)
+	}
+	messageCount := 0
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
@@ -156,6 +385,13 @@ func CalculateLatenciesToFile(logFilePath string) error {
 			continue
 		}
 
+		rec := MetricsRecord{
+			Timestamp: msg.timestamp.Format("2006-01-02 15:04:05.000000"),
+			MsgType:   msg.msgType,
+			ClOrdID:   msg.clOrdID,
+			Fields:    msg.fields,
+		}
+
 		// Track 35=D message timestamps for latency and throughput
 		if msg.msgType == "D" {
 			dMessages[msg.clOrdID] = msg
@@ -167,62 +403,86 @@ func CalculateLatenciesToFile(logFilePath string) error {
 			// Calculate latency
 			if dMsg, found := dMessages[msg.clOrdID]; found {
 				latency := msg.timestamp.Sub(dMsg.timestamp).Milliseconds()
-				latencies = append(latencies, latency)
+				sketch.Add(latency)
+				messageCount++
+				rec.LatencyMs = latency
+				rec.HasLatency = true
 				delete(dMessages, msg.clOrdID) // Remove to avoid multiple calculations for same ClOrdID
 			}
 		}
+
+		if err := formatter.WriteRecord(writer, rec); err != nil {
+			return fmt.Errorf("error writing record: %v", err)
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading file: %v", err)
 	}
 
-	// Write output to the log_metrics file
-	dir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("error getting working directory: %v", err)
+	throughput := make(map[string]int, len(throughputCounts))
+	for minute, count := range throughputCounts {
+		throughput[minute.Format("2006-01-02 15:04")] = count
 	}
-	outputFile, err := os.Create(filepath.Join(dir, "tmp/log_metrics.txt"))
-	if err != nil {
-		return fmt.Errorf("error creating log file: %v", err)
+
+	summary := MetricsSummary{
+		MessageCount: messageCount,
+		Latency:      sketch.Summarize(opts.HistogramBoundsMs),
+		Throughput:   throughput,
+	}
+	if err := formatter.WriteSummary(summaryWriter, summary); err != nil {
+		return fmt.Errorf("error writing summary: %v", err)
 	}
-	defer outputFile.Close()
 
-	writer := bufio.NewWriter(outputFile)
+	summaryWriter.Flush()
+	writer.Flush()
 
-	// Write latency data
-	for _, latency := range latencies {
-		_, err := writer.WriteString(fmt.Sprintf("Latency: %d ms\n", latency))
-		if err != nil {
-			return fmt.Errorf("error writing to log file: %v", err)
+	if opts.CheckpointPath != "" {
+		if err := writeCheckpoint(opts.CheckpointPath, file, info, dMessages, throughputCounts); err != nil {
+			return err
 		}
 	}
 
-	// Calculate average latency
-	averageLatency := float64(0)
-	if len(latencies) > 0 {
-		for _, latency := range latencies {
-			averageLatency += float64(latency)
-		}
-		averageLatency /= float64(len(latencies))
-	}
+	return nil
+}
 
-	// Write the average latency to the log file
-	_, err = writer.WriteString(fmt.Sprintf("Average Latency: %.2f ms\n", averageLatency))
+// writeCheckpoint captures the current scan offset, any unmatched 35=D
+// messages, and the most recent (possibly incomplete) throughput minute,
+// and persists them to checkpointPath.
+func writeCheckpoint(checkpointPath string, file *os.File, info os.FileInfo, dMessages map[string]LogMetricsEntry, throughputCounts map[time.Time]int) error {
+	offset, err := file.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return fmt.Errorf("error writing average latency to log file: %v", err)
+		return fmt.Errorf("error reading scan offset: %v", err)
 	}
 
-	// Write throughput data
-	for minute, count := range throughputCounts {
-		throughputStr := fmt.Sprintf("Minute: %s, Throughput: %d orders/min\n", minute.Format("2006-01-02 15:04"), count)
-		_, err := writer.WriteString(throughputStr)
-		if err != nil {
-			return fmt.Errorf("error writing throughput to log file: %v", err)
+	pending := make(map[string]checkpointDMessage, len(dMessages))
+	for clOrdID, msg := range dMessages {
+		pending[clOrdID] = checkpointDMessage{
+			Timestamp: msg.timestamp,
+			MsgType:   msg.msgType,
+			ClOrdID:   msg.clOrdID,
+			Fields:    msg.fields,
 		}
 	}
 
-	writer.Flush()
+	// Only the most recent minute bucket may still be receiving 35=D
+	// messages on the next run; earlier minutes are already final.
+	partial := make(map[string]int)
+	var latest time.Time
+	for minute := range throughputCounts {
+		if minute.After(latest) {
+			latest = minute
+		}
+	}
+	if !latest.IsZero() {
+		partial[latest.Format("2006-01-02 15:04")] = throughputCounts[latest]
+	}
 
-	return nil
+	return saveCheckpoint(checkpointPath, Checkpoint{
+		File:                  identifyFile(info),
+		FileSize:              info.Size(),
+		Offset:                offset,
+		PendingDMessages:      pending,
+		ThroughputPartialMins: partial,
+	})
 }
\ No newline at end of file
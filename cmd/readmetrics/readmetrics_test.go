@@ -0,0 +1,70 @@
+package readmetrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func benchmarkLogLine() string {
+	return "2024/01/01 00:00:00.000000 8=FIX.4.4\x019=128\x0135=D\x0149=SENDER\x0156=TARGET\x0111=ORD123456\x0155=AAPL\x0152=20240101-00:00:00.000\x0110=000\x01"
+}
+
+// legacyParseFIXMessage reproduces the pre-fixparse strings.Split(line,
+// "\x01") approach, kept here only so BenchmarkLegacySplitParse can be
+// compared against BenchmarkParseFIXMessage, which goes through
+// fixparse.DecodeMessageString.
+func legacyParseFIXMessage(line string) (LogMetricsEntry, error) {
+	fields := strings.Split(line, "\x01")
+	msg := LogMetricsEntry{fields: make(map[string]string)}
+
+	for _, field := range fields {
+		keyValue := strings.SplitN(field, "=", 2)
+		if len(keyValue) != 2 {
+			continue
+		}
+		tag, value := keyValue[0], keyValue[1]
+		msg.fields[tag] = value
+
+		if strings.HasPrefix(field, "35=") {
+			msg.msgType = strings.TrimPrefix(field, "35=")
+		} else if strings.HasPrefix(field, "11=") {
+			msg.clOrdID = strings.TrimPrefix(field, "11=")
+		}
+	}
+	return msg, nil
+}
+
+func TestParseFIXMessage(t *testing.T) {
+	msg, err := parseFIXMessage(benchmarkLogLine())
+	if err != nil {
+		t.Fatalf("parseFIXMessage() error: %v", err)
+	}
+	if msg.msgType != "D" {
+		t.Errorf("msgType = %q, want %q", msg.msgType, "D")
+	}
+	if msg.clOrdID != "ORD123456" {
+		t.Errorf("clOrdID = %q, want %q", msg.clOrdID, "ORD123456")
+	}
+}
+
+func BenchmarkParseFIXMessage(b *testing.B) {
+	line := benchmarkLogLine()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := parseFIXMessage(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLegacySplitParse(b *testing.B) {
+	line := benchmarkLogLine()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyParseFIXMessage(line); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package readmetrics
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// defaultHistogramBoundsMs are the default upper bounds, in milliseconds,
+// of the latency histogram buckets written alongside the summary.
+var defaultHistogramBoundsMs = []int64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// reservoirSize bounds how many samples a Sketch keeps for percentile
+// estimation on very large logs, so memory usage doesn't grow with the
+// number of matched messages.
+const reservoirSize = 10000
+
+// HistogramBucket counts how many latencies fell at or below
+// UpperBoundMs (and above the previous bucket's bound).
+type HistogramBucket struct {
+	UpperBoundMs int64
+	Count        int64
+}
+
+// LatencyStats is a full statistical summary of a set of latencies.
+type LatencyStats struct {
+	Count     int64
+	MinMs     int64
+	MaxMs     int64
+	MeanMs    float64
+	StdDevMs  float64
+	P50Ms     float64
+	P90Ms     float64
+	P95Ms     float64
+	P99Ms     float64
+	P999Ms    float64
+	Histogram []HistogramBucket
+}
+
+// Sketch accumulates latency samples and produces a LatencyStats summary.
+// exactSketch keeps every sample (suitable for small logs); reservoirSketch
+// keeps a bounded random sample so memory stays flat for very large logs.
+type Sketch interface {
+	Add(latencyMs int64)
+	Summarize(bucketBoundsMs []int64) LatencyStats
+}
+
+// exactSketch keeps every latency sample in memory.
+type exactSketch struct {
+	samples []int64
+}
+
+// NewExactSketch returns a Sketch that retains every sample, giving exact
+// percentiles at the cost of O(n) memory.
+func NewExactSketch() Sketch {
+	return &exactSketch{}
+}
+
+func (s *exactSketch) Add(latencyMs int64) {
+	s.samples = append(s.samples, latencyMs)
+}
+
+func (s *exactSketch) Summarize(bucketBoundsMs []int64) LatencyStats {
+	return summarize(s.samples, bucketBoundsMs)
+}
+
+// reservoirSketch keeps a fixed-size uniform random sample of the
+// latencies seen so far (reservoir sampling, Algorithm R), so memory
+// stays bounded regardless of how many messages are processed.
+type reservoirSketch struct {
+	capacity  int
+	seen      int64
+	reservoir []int64
+	rng       *rand.Rand
+}
+
+// NewReservoirSketch returns a Sketch backed by a reservoir of at most
+// capacity samples, suitable for streaming over multi-GB logs.
+func NewReservoirSketch(capacity int) Sketch {
+	if capacity <= 0 {
+		capacity = reservoirSize
+	}
+	return &reservoirSketch{
+		capacity: capacity,
+		rng:      rand.New(rand.NewSource(1)),
+	}
+}
+
+func (s *reservoirSketch) Add(latencyMs int64) {
+	s.seen++
+	if len(s.reservoir) < s.capacity {
+		s.reservoir = append(s.reservoir, latencyMs)
+		return
+	}
+	if j := s.rng.Int63n(s.seen); j < int64(s.capacity) {
+		s.reservoir[j] = latencyMs
+	}
+}
+
+func (s *reservoirSketch) Summarize(bucketBoundsMs []int64) LatencyStats {
+	stats := summarize(s.reservoir, bucketBoundsMs)
+	stats.Count = s.seen
+	return stats
+}
+
+// summarize computes min/max/mean/stddev/percentiles and a bucketed
+// histogram over samples. It mutates a sorted copy internally and does
+// not modify samples.
+func summarize(samples []int64, bucketBoundsMs []int64) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{Histogram: histogram(nil, bucketBoundsMs)}
+	}
+
+	sorted := append([]int64(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum float64
+	for _, v := range sorted {
+		sum += float64(v)
+	}
+	mean := sum / float64(len(sorted))
+
+	var sqDiffSum float64
+	for _, v := range sorted {
+		diff := float64(v) - mean
+		sqDiffSum += diff * diff
+	}
+	stdDev := math.Sqrt(sqDiffSum / float64(len(sorted)))
+
+	return LatencyStats{
+		Count:     int64(len(samples)),
+		MinMs:     sorted[0],
+		MaxMs:     sorted[len(sorted)-1],
+		MeanMs:    mean,
+		StdDevMs:  stdDev,
+		P50Ms:     percentile(sorted, 0.50),
+		P90Ms:     percentile(sorted, 0.90),
+		P95Ms:     percentile(sorted, 0.95),
+		P99Ms:     percentile(sorted, 0.99),
+		P999Ms:    percentile(sorted, 0.999),
+		Histogram: histogram(sorted, bucketBoundsMs),
+	}
+}
+
+// percentile returns the value at the given percentile (0-1) of a
+// pre-sorted slice, linearly interpolating between the two nearest ranks.
+func percentile(sorted []int64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return float64(sorted[lower])
+	}
+	weight := rank - float64(lower)
+	return float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight
+}
+
+// histogram buckets sorted samples into the given upper bounds, with a
+// final overflow bucket (UpperBoundMs == -1) for anything above the
+// highest bound.
+func histogram(sorted []int64, bucketBoundsMs []int64) []HistogramBucket {
+	if len(bucketBoundsMs) == 0 {
+		bucketBoundsMs = defaultHistogramBoundsMs
+	}
+
+	buckets := make([]HistogramBucket, len(bucketBoundsMs)+1)
+	for i, bound := range bucketBoundsMs {
+		buckets[i].UpperBoundMs = bound
+	}
+	buckets[len(buckets)-1].UpperBoundMs = -1 // overflow
+
+	for _, v := range sorted {
+		placed := false
+		for i, bound := range bucketBoundsMs {
+			if v <= bound {
+				buckets[i].Count++
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			buckets[len(buckets)-1].Count++
+		}
+	}
+	return buckets
+}
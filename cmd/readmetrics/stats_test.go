@@ -0,0 +1,92 @@
+package readmetrics
+
+import "testing"
+
+func TestPercentile(t *testing.T) {
+	sorted := []int64{10, 20, 30, 40, 50}
+
+	tests := []struct {
+		name string
+		p    float64
+		want float64
+	}{
+		{name: "p0 is the minimum", p: 0, want: 10},
+		{name: "p50 lands exactly on a sample", p: 0.50, want: 30},
+		{name: "p100-equivalent is the maximum", p: 1, want: 50},
+		{name: "p90 interpolates between samples", p: 0.90, want: 46},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(sorted, tt.p); got != tt.want {
+				t.Errorf("percentile(%v, %v) = %v, want %v", sorted, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercentileEmpty(t *testing.T) {
+	if got := percentile(nil, 0.50); got != 0 {
+		t.Errorf("percentile(nil, 0.50) = %v, want 0", got)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	bounds := []int64{10, 20, 30}
+
+	tests := []struct {
+		name    string
+		samples []int64
+		want    []int64 // expected count per bucket, in bound order plus overflow
+	}{
+		{
+			name:    "sample exactly on a bound falls in that bucket, not the next",
+			samples: []int64{10},
+			want:    []int64{1, 0, 0, 0},
+		},
+		{
+			name:    "sample exactly on the highest bound does not overflow",
+			samples: []int64{30},
+			want:    []int64{0, 0, 1, 0},
+		},
+		{
+			name:    "sample one above the highest bound overflows",
+			samples: []int64{31},
+			want:    []int64{0, 0, 0, 1},
+		},
+		{
+			name:    "sample below the lowest bound falls in the first bucket",
+			samples: []int64{1},
+			want:    []int64{1, 0, 0, 0},
+		},
+		{
+			name:    "empty samples produce empty, fully-populated buckets",
+			samples: nil,
+			want:    []int64{0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := histogram(tt.samples, bounds)
+			if len(buckets) != len(tt.want) {
+				t.Fatalf("got %d buckets, want %d", len(buckets), len(tt.want))
+			}
+			for i, bucket := range buckets {
+				if bucket.Count != tt.want[i] {
+					t.Errorf("bucket[%d] (UpperBoundMs=%d) count = %d, want %d", i, bucket.UpperBoundMs, bucket.Count, tt.want[i])
+				}
+			}
+			if buckets[len(buckets)-1].UpperBoundMs != -1 {
+				t.Errorf("last bucket UpperBoundMs = %d, want -1 (overflow)", buckets[len(buckets)-1].UpperBoundMs)
+			}
+		})
+	}
+}
+
+func TestHistogramDefaultBounds(t *testing.T) {
+	buckets := histogram([]int64{1}, nil)
+	if len(buckets) != len(defaultHistogramBoundsMs)+1 {
+		t.Errorf("got %d buckets, want %d", len(buckets), len(defaultHistogramBoundsMs)+1)
+	}
+}
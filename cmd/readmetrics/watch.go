@@ -0,0 +1,229 @@
+package readmetrics
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// watchPollInterval is how often Watch checks the log file for new data
+// and for rotation/truncation.
+const watchPollInterval = 500 * time.Millisecond
+
+// dMessageTTL bounds how long an unmatched 35=D entry is kept around
+// waiting for its 35=8 ack before Watch drops it, so a long-running
+// follow doesn't leak memory for orders that never get acked.
+const dMessageTTL = 5 * time.Minute
+
+// Event is one of OrderSent, OrderAcked, ThroughputTick, or MessageSeen,
+// delivered to the handler passed to Watch.
+type Event interface{}
+
+// OrderSent is emitted for every 35=D message.
+type OrderSent struct {
+	ClOrdID   string
+	Timestamp time.Time
+	Fields    map[string]string
+}
+
+// OrderAcked is emitted when a 35=8 message matches a previously seen
+// 35=D by ClOrdID.
+type OrderAcked struct {
+	ClOrdID string
+	Latency time.Duration
+	Fields  map[string]string
+}
+
+// ThroughputTick is emitted once a minute bucket of 35=D messages is
+// complete, reporting how many orders were sent in that minute.
+type ThroughputTick struct {
+	Minute time.Time
+	Count  int
+}
+
+// MessageSeen is emitted for every parsed message not already reported by
+// OrderSent/OrderAcked (anything other than 35=D/35=8, such as heartbeats
+// or rejects, plus any 35=8 that matches no pending 35=D), so consumers
+// that want a per-MsgType view of the log see every message.
+type MessageSeen struct {
+	MsgType   string
+	Timestamp time.Time
+}
+
+// Handler receives events produced by Watch.
+type Handler func(Event)
+
+// fanOut returns a Handler that forwards every event to each of handlers
+// in order, so a single Watch call can drive multiple consumers (e.g. the
+// --follow printer and the Prometheus exporter) off one parser loop.
+func fanOut(handlers ...Handler) Handler {
+	return func(event Event) {
+		for _, h := range handlers {
+			h(event)
+		}
+	}
+}
+
+// dEntry tracks an unmatched 35=D message alongside the time it was
+// observed, so Watch can expire it after dMessageTTL.
+type dEntry struct {
+	msg  LogMetricsEntry
+	seen time.Time
+}
+
+// Watch opens path and follows it like `tail -f`, parsing newly appended
+// FIX messages and delivering OrderSent, OrderAcked, ThroughputTick, and
+// MessageSeen events to handler. It detects file rotation or truncation (by inode
+// change or size shrink), reopens the file, and keeps matching 35=D/35=8
+// pairs across the rotation. Watch blocks until ctx is canceled.
+func Watch(ctx context.Context, path string, handler Handler) error {
+	file, info, err := openForWatch(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	dMessages := make(map[string]dEntry)
+	throughputCounts := make(map[time.Time]int)
+	reportedMinutes := make(map[time.Time]bool)
+	var pending string
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			newInfo, statErr := os.Stat(path)
+			if statErr == nil && (!os.SameFile(info, newInfo) || newInfo.Size() < mustTell(file)) {
+				newFile, reopenedInfo, err := openForWatch(path)
+				if err != nil {
+					return err
+				}
+				file.Close()
+				file, info = newFile, reopenedInfo
+				reader = bufio.NewReader(file)
+				pending = ""
+			}
+
+			var err error
+			pending, err = drainLines(reader, pending, dMessages, throughputCounts, reportedMinutes, handler)
+			if err != nil && err != io.EOF {
+				return err
+			}
+
+			expireStale(dMessages, dMessageTTL)
+		}
+	}
+}
+
+// openForWatch opens path at its current end-of-file, so Watch only
+// follows newly appended lines rather than replaying history.
+func openForWatch(path string) (*os.File, os.FileInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error stat-ing log file: %v", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error seeking to end of log file: %v", err)
+	}
+	return file, info, nil
+}
+
+// mustTell reports the file's current read offset, or 0 if it can't be
+// determined (e.g. the file was already closed during rotation).
+func mustTell(file *os.File) int64 {
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0
+	}
+	return offset
+}
+
+// drainLines reads and processes every complete line currently available
+// from reader, updating dMessages/throughputCounts and emitting events to
+// handler. pending is any partial line left over from a previous call
+// (reader.ReadString returns its partial read alongside io.EOF rather than
+// waiting for the rest of the line to be written); drainLines prepends it
+// to the next read instead of processing it as a complete line, and
+// returns whatever is left unterminated for the next call.
+func drainLines(reader *bufio.Reader, pending string, dMessages map[string]dEntry, throughputCounts map[time.Time]int, reportedMinutes map[time.Time]bool, handler Handler) (string, error) {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return pending + line, err
+		}
+		processWatchLine(pending+line, dMessages, throughputCounts, reportedMinutes, handler)
+		pending = ""
+	}
+}
+
+// processWatchLine parses a single log line and, if it is a 35=D or
+// 35=8 message, updates state and emits the corresponding events.
+func processWatchLine(line string, dMessages map[string]dEntry, throughputCounts map[time.Time]int, reportedMinutes map[time.Time]bool, handler Handler) {
+	// reader.ReadString('\n') in drainLines keeps the trailing newline,
+	// unlike bufio.Scanner.Text() in CalculateLatenciesToFile; trim it so
+	// parseFIXMessage doesn't choke on a trailing "\n" field.
+	msg, err := parseFIXMessage(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		return
+	}
+
+	switch msg.msgType {
+	case "D":
+		dMessages[msg.clOrdID] = dEntry{msg: msg, seen: msg.timestamp}
+		handler(OrderSent{ClOrdID: msg.clOrdID, Timestamp: msg.timestamp, Fields: msg.fields})
+
+		minute := msg.timestamp.Truncate(time.Minute)
+		throughputCounts[minute]++
+		for reportedMinute := range throughputCounts {
+			if reportedMinute.Before(minute) && !reportedMinutes[reportedMinute] {
+				reportedMinutes[reportedMinute] = true
+				handler(ThroughputTick{Minute: reportedMinute, Count: throughputCounts[reportedMinute]})
+			}
+		}
+	case "8":
+		if msg.clOrdID == "" {
+			handler(MessageSeen{MsgType: msg.msgType, Timestamp: msg.timestamp})
+			return
+		}
+		if entry, found := dMessages[msg.clOrdID]; found {
+			latency := msg.timestamp.Sub(entry.msg.timestamp)
+			// Use the original 35=D's fields, not the 35=8 ack's own: FIX
+			// swaps SenderCompID(49)/TargetCompID(56) between a request and
+			// its response, so an ack's own header would record this order
+			// under the opposite {sender,target} label pair from the
+			// OrderSent it's meant to match.
+			handler(OrderAcked{ClOrdID: msg.clOrdID, Latency: latency, Fields: entry.msg.fields})
+			delete(dMessages, msg.clOrdID)
+		} else {
+			handler(MessageSeen{MsgType: msg.msgType, Timestamp: msg.timestamp})
+		}
+	default:
+		handler(MessageSeen{MsgType: msg.msgType, Timestamp: msg.timestamp})
+	}
+}
+
+// expireStale drops dMessages entries older than ttl so unmatched 35=D
+// messages don't accumulate forever.
+func expireStale(dMessages map[string]dEntry, ttl time.Duration) {
+	cutoff := time.Now().Add(-ttl)
+	for clOrdID, entry := range dMessages {
+		if entry.seen.Before(cutoff) {
+			delete(dMessages, clOrdID)
+		}
+	}
+}
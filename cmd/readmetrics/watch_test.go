@@ -0,0 +1,118 @@
+package readmetrics
+
+import (
+	"bufio"
+	"io"
+	"testing"
+	"time"
+)
+
+// fakeTailReader simulates a file being appended to across separate
+// drainLines calls: Read only ever returns the bytes appended so far,
+// reporting io.EOF once they're exhausted, same as an *os.File at EOF.
+type fakeTailReader struct {
+	data      []byte
+	delivered int
+}
+
+func (f *fakeTailReader) Read(p []byte) (int, error) {
+	if f.delivered >= len(f.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[f.delivered:])
+	f.delivered += n
+	return n, nil
+}
+
+func TestProcessWatchLineOrderAckedUsesOriginalDFields(t *testing.T) {
+	// 49/56 (SenderCompID/TargetCompID) are swapped on the 35=8 ack
+	// relative to the 35=D it's acking, as real FIX traffic does.
+	sent := "2024/01/01 00:00:00.000000 8=FIX.4.4\x0135=D\x0111=ORDZ\x0149=CLIENT\x0156=EXCHANGE\x0155=AMD\x0152=20240101-00:00:00.000\x0110=000\x01"
+	ack := "2024/01/01 00:00:00.100000 8=FIX.4.4\x0135=8\x0111=ORDZ\x0149=EXCHANGE\x0156=CLIENT\x0155=AMD\x0152=20240101-00:00:00.100\x0110=000\x01"
+
+	dMessages := make(map[string]dEntry)
+	throughputCounts := make(map[time.Time]int)
+	reportedMinutes := make(map[time.Time]bool)
+	var got []Event
+	handler := func(e Event) { got = append(got, e) }
+
+	processWatchLine(sent, dMessages, throughputCounts, reportedMinutes, handler)
+	processWatchLine(ack, dMessages, throughputCounts, reportedMinutes, handler)
+
+	var acked *OrderAcked
+	for _, e := range got {
+		if a, ok := e.(OrderAcked); ok {
+			acked = &a
+		}
+	}
+	if acked == nil {
+		t.Fatalf("got %#v, want an OrderAcked event", got)
+	}
+	if acked.Fields["49"] != "CLIENT" || acked.Fields["56"] != "EXCHANGE" {
+		t.Errorf("OrderAcked.Fields[49/56] = %q/%q, want the original 35=D's CLIENT/EXCHANGE, not the ack's swapped header", acked.Fields["49"], acked.Fields["56"])
+	}
+}
+
+func TestDrainLinesBuffersPartialLineAcrossCalls(t *testing.T) {
+	const full = "2024/01/01 00:00:40.000000 8=FIX.4.4\x0135=D\x0111=ORDZ\x0149=SNDR\x0156=TGT\x0155=AMD\x0152=20240101-00:00:40.000\x0110=000\x01\n"
+	split := len(full) / 2
+
+	tail := &fakeTailReader{data: []byte(full[:split])}
+	reader := bufio.NewReader(tail)
+
+	dMessages := make(map[string]dEntry)
+	throughputCounts := make(map[time.Time]int)
+	reportedMinutes := make(map[time.Time]bool)
+	var got []Event
+	handler := func(e Event) { got = append(got, e) }
+
+	pending, err := drainLines(reader, "", dMessages, throughputCounts, reportedMinutes, handler)
+	if err != io.EOF {
+		t.Fatalf("first drainLines() error = %v, want io.EOF", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("got %d events after a partial line, want 0 (must not process an incomplete line)", len(got))
+	}
+	if pending != full[:split] {
+		t.Fatalf("pending = %q, want the unterminated partial line %q", pending, full[:split])
+	}
+
+	tail.data = []byte(full)
+	pending, err = drainLines(reader, pending, dMessages, throughputCounts, reportedMinutes, handler)
+	if err != io.EOF {
+		t.Fatalf("second drainLines() error = %v, want io.EOF", err)
+	}
+	if pending != "" {
+		t.Errorf("pending = %q, want \"\" once the line is complete", pending)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d events once the rest of the line arrived, want 1", len(got))
+	}
+	sent, ok := got[0].(OrderSent)
+	if !ok {
+		t.Fatalf("event = %#v, want OrderSent", got[0])
+	}
+	if sent.ClOrdID != "ORDZ" {
+		t.Errorf("ClOrdID = %q, want %q", sent.ClOrdID, "ORDZ")
+	}
+}
+
+func TestProcessWatchLineTrimsTrailingNewline(t *testing.T) {
+	line := "2024/01/01 00:00:40.000000 8=FIX.4.4\x0135=D\x0111=ORDZ\x0149=SNDR\x0156=TGT\x0155=AMD\x0152=20240101-00:00:40.000\x0110=000\x01\n"
+
+	var got []Event
+	processWatchLine(line, make(map[string]dEntry), make(map[time.Time]int), make(map[time.Time]bool), func(e Event) {
+		got = append(got, e)
+	})
+
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1 (trailing newline from ReadString should not break parsing)", len(got))
+	}
+	sent, ok := got[0].(OrderSent)
+	if !ok {
+		t.Fatalf("event = %#v, want OrderSent", got[0])
+	}
+	if sent.ClOrdID != "ORDZ" {
+		t.Errorf("ClOrdID = %q, want %q", sent.ClOrdID, "ORDZ")
+	}
+}
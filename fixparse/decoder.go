@@ -0,0 +1,140 @@
+// Package fixparse decodes FIX protocol messages whose fields are
+// delimited by the SOH (0x01) byte, as specified by the FIX protocol,
+// rather than by whitespace or tag order.
+package fixparse
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// soh is the FIX field delimiter (Start of Heading, 0x01).
+const soh = 0x01
+
+// Decoder reads SOH-delimited tag=value fields from a byte stream one
+// field at a time, without allocating per field: NextField reuses its
+// internal buffer, so the returned value is only valid until the next
+// call.
+type Decoder struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewDecoder returns a Decoder that reads FIX fields from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), buf: make([]byte, 0, 64)}
+}
+
+// NextField reads the next SOH-delimited tag=value field. It returns
+// io.EOF once the stream is exhausted. The returned value slice aliases
+// the Decoder's internal buffer and is only valid until the next call
+// to NextField.
+func (d *Decoder) NextField() (tag int, value []byte, err error) {
+	d.buf = d.buf[:0]
+	for {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(d.buf) > 0 {
+				break
+			}
+			return 0, nil, err
+		}
+		if b == soh {
+			break
+		}
+		d.buf = append(d.buf, b)
+	}
+
+	eq := bytes.IndexByte(d.buf, '=')
+	if eq < 0 {
+		return 0, nil, fmt.Errorf("fixparse: malformed field %q", d.buf)
+	}
+	tag, err = strconv.Atoi(string(d.buf[:eq]))
+	if err != nil {
+		return 0, nil, fmt.Errorf("fixparse: invalid tag in field %q: %v", d.buf, err)
+	}
+	return tag, d.buf[eq+1:], nil
+}
+
+// Message is a decoded FIX message with typed accessors for the tags
+// readmetrics cares about most. Fields are kept string-keyed internally
+// (rather than by their int tag) since every caller ultimately wants them
+// that way (Fields, Tag), which avoids a second int->string conversion
+// pass over every field decoded.
+type Message struct {
+	fields map[string]string
+}
+
+// DecodeMessage reads every SOH-delimited field from r and assembles
+// them into a Message.
+func DecodeMessage(r io.Reader) (Message, error) {
+	msg := Message{fields: make(map[string]string)}
+	dec := NewDecoder(r)
+	for {
+		tag, value, err := dec.NextField()
+		if err != nil {
+			if err == io.EOF {
+				return msg, nil
+			}
+			return msg, err
+		}
+		msg.fields[strconv.Itoa(tag)] = string(value)
+	}
+}
+
+// DecodeMessageString decodes FIX fields directly from s, the common case
+// on readmetrics' hot path where the whole message is already a string in
+// memory (e.g. one log line). Unlike DecodeMessage, it does not wrap s in
+// a bufio.Reader, so it avoids that allocation and the per-byte ReadByte
+// call overhead entirely.
+func DecodeMessageString(s string) (Message, error) {
+	msg := Message{fields: make(map[string]string)}
+	for len(s) > 0 {
+		field := s
+		if i := strings.IndexByte(s, soh); i >= 0 {
+			field, s = s[:i], s[i+1:]
+		} else {
+			s = ""
+		}
+
+		eq := strings.IndexByte(field, '=')
+		if eq < 0 {
+			return msg, fmt.Errorf("fixparse: malformed field %q", field)
+		}
+		tagStr := field[:eq]
+		if _, err := strconv.Atoi(tagStr); err != nil {
+			return msg, fmt.Errorf("fixparse: invalid tag in field %q: %v", field, err)
+		}
+		msg.fields[tagStr] = field[eq+1:]
+	}
+	return msg, nil
+}
+
+// MsgType returns tag 35 (MsgType).
+func (m Message) MsgType() string { return m.fields["35"] }
+
+// ClOrdID returns tag 11 (ClOrdID).
+func (m Message) ClOrdID() string { return m.fields["11"] }
+
+// SendingTime returns tag 52 (SendingTime).
+func (m Message) SendingTime() string { return m.fields["52"] }
+
+// Tag returns the raw value of an arbitrary FIX tag.
+func (m Message) Tag(tag int) (string, bool) {
+	value, ok := m.fields[strconv.Itoa(tag)]
+	return value, ok
+}
+
+// Fields returns every tag in the message, keyed by its string tag
+// number, for callers that need to carry the full field set (e.g.
+// readmetrics.MetricsRecord.Fields). Each call to DecodeMessage or
+// DecodeMessageString builds its own fields map, so the returned map is
+// not shared with any other Message and is safe for the caller to keep
+// or mutate.
+func (m Message) Fields() map[string]string {
+	return m.fields
+}
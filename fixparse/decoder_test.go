@@ -0,0 +1,135 @@
+package fixparse
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderNextField(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantTag   int
+		wantValue string
+		wantErr   bool
+	}{
+		{name: "simple field", input: "35=D\x01", wantTag: 35, wantValue: "D"},
+		{name: "empty value", input: "58=\x01", wantTag: 58, wantValue: ""},
+		{name: "missing equals", input: "garbage\x01", wantErr: true},
+		{name: "non-numeric tag", input: "abc=1\x01", wantErr: true},
+		{name: "final field without trailing SOH", input: "10=128", wantTag: 10, wantValue: "128"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			tag, value, err := dec.NextField()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NextField() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NextField() unexpected error: %v", err)
+			}
+			if tag != tt.wantTag {
+				t.Errorf("tag = %d, want %d", tag, tt.wantTag)
+			}
+			if string(value) != tt.wantValue {
+				t.Errorf("value = %q, want %q", value, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestDecoderNextFieldEOF(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(""))
+	if _, _, err := dec.NextField(); err != io.EOF {
+		t.Fatalf("NextField() on empty reader error = %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	msg, err := DecodeMessage(strings.NewReader("8=FIX.4.4\x0135=D\x0111=ORD123\x0152=20240101-00:00:00\x0110=000\x01"))
+	if err != nil {
+		t.Fatalf("DecodeMessage() error: %v", err)
+	}
+
+	if got := msg.MsgType(); got != "D" {
+		t.Errorf("MsgType() = %q, want %q", got, "D")
+	}
+	if got := msg.ClOrdID(); got != "ORD123" {
+		t.Errorf("ClOrdID() = %q, want %q", got, "ORD123")
+	}
+	if got := msg.SendingTime(); got != "20240101-00:00:00" {
+		t.Errorf("SendingTime() = %q, want %q", got, "20240101-00:00:00")
+	}
+	if value, ok := msg.Tag(8); !ok || value != "FIX.4.4" {
+		t.Errorf("Tag(8) = (%q, %v), want (\"FIX.4.4\", true)", value, ok)
+	}
+	if _, ok := msg.Tag(999); ok {
+		t.Errorf("Tag(999) ok = true, want false for an absent tag")
+	}
+
+	fields := msg.Fields()
+	if len(fields) != 5 {
+		t.Errorf("Fields() returned %d entries, want 5", len(fields))
+	}
+}
+
+func TestDecodeMessageString(t *testing.T) {
+	msg, err := DecodeMessageString("8=FIX.4.4\x0135=D\x0111=ORD123\x0152=20240101-00:00:00\x0110=000\x01")
+	if err != nil {
+		t.Fatalf("DecodeMessageString() error: %v", err)
+	}
+	if got := msg.MsgType(); got != "D" {
+		t.Errorf("MsgType() = %q, want %q", got, "D")
+	}
+	if got := msg.ClOrdID(); got != "ORD123" {
+		t.Errorf("ClOrdID() = %q, want %q", got, "ORD123")
+	}
+}
+
+func TestDecodeMessageStringNoTrailingSOH(t *testing.T) {
+	msg, err := DecodeMessageString("35=D\x0111=ORD123")
+	if err != nil {
+		t.Fatalf("DecodeMessageString() error: %v", err)
+	}
+	if got := msg.ClOrdID(); got != "ORD123" {
+		t.Errorf("ClOrdID() = %q, want %q", got, "ORD123")
+	}
+}
+
+func TestDecodeMessageStringMalformed(t *testing.T) {
+	if _, err := DecodeMessageString("garbage\x01"); err == nil {
+		t.Fatalf("DecodeMessageString() error = nil, want error for a field with no '='")
+	}
+}
+
+func benchmarkMessage() string {
+	return "8=FIX.4.4\x019=128\x0135=D\x0149=SENDER\x0156=TARGET\x0111=ORD123456\x0155=AAPL\x0152=20240101-00:00:00.000\x0110=000\x01"
+}
+
+func BenchmarkDecodeMessage(b *testing.B) {
+	msg := benchmarkMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeMessage(strings.NewReader(msg)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeMessageString(b *testing.B) {
+	msg := benchmarkMessage()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DecodeMessageString(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}